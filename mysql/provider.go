@@ -2,8 +2,12 @@ package mysql
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"database/sql"
 	"fmt"
+	"io/ioutil"
+	"log"
 	"net"
 	"net/url"
 	"regexp"
@@ -17,22 +21,38 @@ import (
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
 	"github.com/Azure/go-autorest/autorest/azure/auth"
 
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/rds/rdsutils"
+
+	"cloud.google.com/go/cloudsqlconn"
+
 	"golang.org/x/net/proxy"
+
+	"github.com/terraform-providers/terraform-provider-mysql/mysql/dbconnect"
 )
 
 const (
 	cleartextPasswords = "cleartext"
 	nativePasswords    = "native"
 	aadAuthentication  = "aad_auth"
+	iamAuthentication  = "iam_auth"
 )
 
 type MySQLConfiguration struct {
 	Config              *mysql.Config
+	Endpoints           []string
+	RequirePrimary      bool
 	MaxConnLifetime     time.Duration
 	MaxOpenConns        int
 	ConnectRetryTimeout time.Duration
+	TokenProvider       func(host string) (string, error)
+	ActiveEndpoint      string
 	db                  *sql.DB
 }
 
@@ -54,6 +74,7 @@ func Provider() *schema.Provider {
 				Type:        schema.TypeString,
 				Required:    true,
 				DefaultFunc: schema.EnvDefaultFunc("MYSQL_ENDPOINT", nil),
+				Description: "host:port, /path/to/socket, a go-sql-driver DSN, or a mysql:// URL, for a single server. Multiple failover targets may be given as a comma- or \"||\"-separated list of host:port values, e.g. \"10.0.0.1:3306,10.0.0.2:3306\"",
 				ValidateFunc: func(v interface{}, k string) (ws []string, errors []error) {
 					value := v.(string)
 					if value == "" {
@@ -97,6 +118,37 @@ func Provider() *schema.Provider {
 				}, false),
 			},
 
+			"tls_ca_cert": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "PEM-encoded CA certificate used to verify the server, given inline. Takes precedence over tls_ca_cert_file. Setting this (or any other tls_* field) registers a custom TLS config and implies tls = \"true\" unless already set to \"skip-verify\"",
+			},
+
+			"tls_ca_cert_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Path to a PEM-encoded CA certificate used to verify the server",
+			},
+
+			"tls_client_cert": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "PEM-encoded client certificate for mutual TLS, given inline or as a path to a file containing it. Requires tls_client_key",
+			},
+
+			"tls_client_key": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Sensitive:   true,
+				Description: "PEM-encoded client private key for mutual TLS, given inline or as a path to a file containing it. Requires tls_client_cert",
+			},
+
+			"tls_server_name": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Server name to verify the certificate presented by the server against, overriding the host derived from endpoint",
+			},
+
 			"max_conn_lifetime_sec": {
 				Type:     schema.TypeInt,
 				Optional: true,
@@ -111,7 +163,7 @@ func Provider() *schema.Provider {
 				Type:         schema.TypeString,
 				Optional:     true,
 				Default:      nativePasswords,
-				ValidateFunc: validation.StringInSlice([]string{cleartextPasswords, nativePasswords, aadAuthentication}, true),
+				ValidateFunc: validation.StringInSlice([]string{cleartextPasswords, nativePasswords, aadAuthentication, iamAuthentication}, true),
 			},
 
 			"aad_auth_client_id": {
@@ -127,6 +179,66 @@ func Provider() *schema.Provider {
 				Description: "Azure AD Client ID, required when using AAD Auth Plugin",
 			},
 
+			"aad_use_msi": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "When authentication_plugin is aad_auth and no password (client secret) is given, restrict token acquisition to Azure managed identity rather than also falling back to workload identity and the az CLI",
+			},
+
+			"aad_msi_client_id": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Client ID of a user-assigned managed identity to use for AAD token acquisition when authentication_plugin is aad_auth and no password is given",
+			},
+
+			"aws_region": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("AWS_REGION", nil),
+				Description: "AWS region of the RDS/Aurora instance, required when using IAM Auth Plugin",
+			},
+
+			"aws_profile": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("AWS_PROFILE", nil),
+				Description: "AWS named profile to use when resolving credentials for IAM Auth Plugin",
+			},
+
+			"aws_assume_role_arn": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "ARN of an IAM role to assume when generating IAM Auth Plugin tokens",
+			},
+
+			"cloudsql_connection_name": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CLOUDSQL_CONNECTION_NAME", nil),
+				Description: "Cloud SQL instance connection name (project:region:instance). When set, connections are proxied through the Cloud SQL Go Connector instead of dialing endpoint directly",
+			},
+
+			"cloudsql_credentials_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Path to a service account JSON credentials file used by the Cloud SQL Go Connector, in place of the ambient credentials chain",
+			},
+
+			"cloudsql_use_iam_auth": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Use Cloud SQL IAM database authentication. Authentication happens inside the connector's TLS handshake (cloudsqlconn.WithIAMAuthN), so no password is required; a password, if set, is still passed to the driver",
+			},
+
+			"require_primary": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "When endpoint lists multiple hosts, skip any host that reports itself as read-only (via SELECT @@read_only) rather than connecting to it",
+			},
+
 			"connect_retry_timeout_sec": {
 				Type:     schema.TypeInt,
 				Optional: true,
@@ -155,54 +267,183 @@ func providerConfigure(d *schema.ResourceData) (interface{}, error) {
 
 	var endpoint = d.Get("endpoint").(string)
 
-	proto := "tcp"
-	if len(endpoint) > 0 && endpoint[0] == '/' {
-		proto = "unix"
+	parsedConf, err := dbconnect.ParseMySQLConnection(endpoint)
+	if err != nil {
+		return nil, err
 	}
+	proto := parsedConf.Net
 
 	var authPlugin = d.Get("authentication_plugin").(string)
+	var username = d.Get("username").(string)
+	if username == "" {
+		username = parsedConf.User
+	}
 	var password = d.Get("password").(string)
+	if password == "" {
+		password = parsedConf.Passwd
+	}
+	// "false" is the tls schema's own default, so treat it the same as the
+	// zero value here: an endpoint URL's own "?tls=..." query (e.g.
+	// "mysql://u:p@h/db?tls=skip-verify") still wins unless tls was set
+	// explicitly.
+	var tlsConfig = d.Get("tls").(string)
+	if tlsConfig == "false" && parsedConf.TLSConfig != "" {
+		tlsConfig = parsedConf.TLSConfig
+	}
+	var tokenProvider func(host string) (string, error)
 
-	if authPlugin == aadAuthentication {
+	customTLSName, err := maybeRegisterCustomTLSConfig(d)
+	if err != nil {
+		return nil, err
+	}
+	if customTLSName != "" {
+		tlsConfig = customTLSName
+	}
+
+	switch authPlugin {
+	case aadAuthentication:
 		authPlugin = cleartextPasswords
 		token, err := getAADToken(d, password)
 		if err != nil {
 			return nil, err
 		}
 		password = token
+	case iamAuthentication:
+		authPlugin = cleartextPasswords
+		// A custom TLS config (tls_ca_cert/tls_client_cert) takes precedence
+		// over the "true" default, since RDS/Aurora commonly require the
+		// RDS CA bundle rather than just opportunistic TLS.
+		if customTLSName == "" {
+			tlsConfig = "true"
+		}
+
+		provider, err := makeRDSAuthTokenProvider(d)
+		if err != nil {
+			return nil, err
+		}
+		tokenProvider = provider
+
+		initialHost := endpoint
+		if hosts := splitEndpoints(endpoint); len(hosts) > 0 {
+			initialHost = hosts[0]
+		}
+
+		token, err := tokenProvider(initialHost)
+		if err != nil {
+			return nil, err
+		}
+		password = token
 	}
 
+	cloudSQLConnName := d.Get("cloudsql_connection_name").(string)
+
 	conf := mysql.Config{
-		User:                    d.Get("username").(string),
+		User:                    username,
 		Passwd:                  password,
 		Net:                     proto,
-		Addr:                    endpoint,
-		TLSConfig:               d.Get("tls").(string),
+		Addr:                    parsedConf.Addr,
+		DBName:                  parsedConf.DBName,
+		TLSConfig:               tlsConfig,
 		AllowNativePasswords:    authPlugin == nativePasswords,
 		AllowCleartextPasswords: authPlugin == cleartextPasswords,
 	}
 
-	dialer, err := makeDialer(d)
-	if err != nil {
-		return nil, err
-	}
+	if cloudSQLConnName != "" {
+		csqlDialer, err := makeCloudSQLDialer(context.Background(), d)
+		if err != nil {
+			return nil, err
+		}
 
-	mysql.RegisterDialContext("tcp", func(ctx context.Context, addr string) (net.Conn, error) {
-		return dialer.Dial("tcp", addr)
-	})
+		mysql.RegisterDialContext("cloudsql", func(ctx context.Context, addr string) (net.Conn, error) {
+			return csqlDialer.Dial(ctx, addr)
+		})
+
+		conf.Net = "cloudsql"
+		conf.Addr = cloudSQLConnName
+		// The connector already negotiates TLS (and rotates the instance's
+		// ephemeral certificate) before handing back a plain net.Conn, so a
+		// second TLS handshake layered on top by the mysql driver fails.
+		conf.TLSConfig = "false"
+
+		if d.Get("cloudsql_use_iam_auth").(bool) {
+			// There is no password to exchange over the wire for IAM
+			// database authentication - the connector authenticates as the
+			// IAM principal during dial - so the cleartext plugin has to be
+			// allowed the same way the RDS/AAD token paths above allow it.
+			conf.AllowCleartextPasswords = true
+			conf.AllowNativePasswords = false
+		}
+	} else {
+		dialer, err := makeDialer(d)
+		if err != nil {
+			return nil, err
+		}
+
+		mysql.RegisterDialContext("tcp", func(ctx context.Context, addr string) (net.Conn, error) {
+			return dialer.Dial("tcp", addr)
+		})
+	}
 
 	mysqlConf := &MySQLConfiguration{
 		Config:              &conf,
+		Endpoints:           splitEndpoints(endpoint),
+		RequirePrimary:      d.Get("require_primary").(bool),
 		MaxConnLifetime:     time.Duration(d.Get("max_conn_lifetime_sec").(int)) * time.Second,
 		MaxOpenConns:        d.Get("max_open_conns").(int),
 		ConnectRetryTimeout: time.Duration(d.Get("connect_retry_timeout_sec").(int)) * time.Second,
+		TokenProvider:       tokenProvider,
 		db:                  nil,
 	}
 
 	return mysqlConf, nil
 }
 
+// splitEndpoints parses the "endpoint" argument into one or more host:port
+// failover targets, accepting either a comma- or "||"-separated list. Each
+// candidate may optionally be wrapped in the go-sql-driver "tcp(...)" form.
+//
+// A URL or go-sql-driver DSN already names a single server, parsed in full
+// by dbconnect.ParseMySQLConnection, so it is returned as-is rather than
+// split on comma - which could otherwise appear inside a DSN/URL query
+// string and produce bogus extra "hosts".
+func splitEndpoints(endpoint string) []string {
+	if strings.Contains(endpoint, "://") || strings.Contains(endpoint, "@") || strings.Contains(endpoint, ")/") {
+		return []string{endpoint}
+	}
+
+	sep := ","
+	if strings.Contains(endpoint, "||") {
+		sep = "||"
+	}
+
+	var hosts []string
+	for _, candidate := range strings.Split(endpoint, sep) {
+		candidate = strings.TrimSpace(candidate)
+		candidate = strings.TrimPrefix(candidate, "tcp(")
+		candidate = strings.TrimSuffix(candidate, ")")
+		if candidate != "" {
+			hosts = append(hosts, candidate)
+		}
+	}
+
+	return hosts
+}
+
+// aadDatabaseScope is the AAD resource scope MySQL Flexible Server / single
+// server expect access tokens to be issued for.
+const aadDatabaseScope = "https://ossrdbms-aad.database.windows.net/.default"
+
+// getAADToken mints an AAD access token to use as the password when
+// authentication_plugin is aad_auth. When a client secret (password) is
+// given it uses the classic service-principal client-credentials flow;
+// otherwise it falls back to getAADWorkloadIdentityToken, since requiring a
+// client secret is impractical in deployments where federated/managed
+// identity is the norm.
 func getAADToken(d *schema.ResourceData, password string) (token string, err error) {
+	if password == "" {
+		return getAADWorkloadIdentityToken(d)
+	}
+
 	clientId, exists := d.GetOk("aad_auth_client_id")
 	if !exists {
 		err = fmt.Errorf("aad_auth_client_id is not set and is required when authentication_plugin is aad_auth")
@@ -214,7 +455,7 @@ func getAADToken(d *schema.ResourceData, password string) (token string, err err
 		return
 	}
 	clientCredentialsConfig := auth.NewClientCredentialsConfig(clientId.(string), password, tenantId.(string))
-	clientCredentialsConfig.AADEndpoint = "https://ossrdbms-aad.database.windows.net/.default"
+	clientCredentialsConfig.AADEndpoint = aadDatabaseScope
 	aadToken, err := clientCredentialsConfig.ServicePrincipalToken()
 	if err != nil {
 		return
@@ -223,6 +464,210 @@ func getAADToken(d *schema.ResourceData, password string) (token string, err err
 	return
 }
 
+// getAADWorkloadIdentityToken mints an AAD access token without a client
+// secret. When aad_use_msi is set it uses Azure managed identity only;
+// otherwise it tries, in order, managed identity (for provider runs inside
+// an Azure VM or AKS pod), AZURE_FEDERATED_TOKEN_FILE workload identity, and
+// finally the az CLI's logged-in session.
+func getAADWorkloadIdentityToken(d *schema.ResourceData) (token string, err error) {
+	var msiOpts azidentity.ManagedIdentityCredentialOptions
+	if clientId, exists := d.GetOk("aad_msi_client_id"); exists {
+		msiOpts.ID = azidentity.ClientID(clientId.(string))
+	}
+
+	var cred azcore.TokenCredential
+	if d.Get("aad_use_msi").(bool) {
+		cred, err = azidentity.NewManagedIdentityCredential(&msiOpts)
+		if err != nil {
+			return "", fmt.Errorf("could not create managed identity credential: %s", err)
+		}
+	} else {
+		var creds []azcore.TokenCredential
+		if msiCred, msiErr := azidentity.NewManagedIdentityCredential(&msiOpts); msiErr == nil {
+			creds = append(creds, msiCred)
+		}
+		if wiCred, wiErr := azidentity.NewWorkloadIdentityCredential(nil); wiErr == nil {
+			creds = append(creds, wiCred)
+		}
+		if cliCred, cliErr := azidentity.NewAzureCLICredential(nil); cliErr == nil {
+			creds = append(creds, cliCred)
+		}
+		if len(creds) == 0 {
+			return "", fmt.Errorf("no Azure credential source (managed identity, workload identity, or az CLI) is available")
+		}
+
+		cred, err = azidentity.NewChainedTokenCredential(creds, nil)
+		if err != nil {
+			return "", fmt.Errorf("could not build Azure credential chain: %s", err)
+		}
+	}
+
+	azToken, err := cred.GetToken(context.Background(), policy.TokenRequestOptions{
+		Scopes: []string{aadDatabaseScope},
+	})
+	if err != nil {
+		return "", fmt.Errorf("could not obtain AAD token from managed identity/workload identity/az CLI: %s", err)
+	}
+
+	return azToken.Token, nil
+}
+
+// makeRDSAuthTokenProvider builds a closure that mints a fresh IAM database
+// authentication token for a given host on each call. RDS/Aurora auth tokens
+// are only valid for about 15 minutes and are host-specific, so the caller
+// is expected to invoke this again - passing whichever host is actually
+// being dialed - on every reconnect rather than caching a single token for
+// the provider's lifetime.
+func makeRDSAuthTokenProvider(d *schema.ResourceData) (func(host string) (string, error), error) {
+	region, exists := d.GetOk("aws_region")
+	if !exists {
+		return nil, fmt.Errorf("aws_region is not set and is required when authentication_plugin is iam_auth")
+	}
+
+	sessOpts := session.Options{SharedConfigState: session.SharedConfigEnable}
+	if profile, exists := d.GetOk("aws_profile"); exists {
+		sessOpts.Profile = profile.(string)
+	}
+
+	sess, err := session.NewSessionWithOptions(sessOpts)
+	if err != nil {
+		return nil, fmt.Errorf("could not create AWS session: %s", err)
+	}
+
+	creds := sess.Config.Credentials
+	if roleArn, exists := d.GetOk("aws_assume_role_arn"); exists {
+		creds = stscreds.NewCredentials(sess, roleArn.(string))
+	}
+
+	username := d.Get("username").(string)
+
+	return func(host string) (string, error) {
+		return rdsutils.BuildAuthToken(host, region.(string), username, creds)
+	}, nil
+}
+
+// makeCloudSQLDialer builds a Cloud SQL Go Connector dialer. The connector
+// keeps the instance's ephemeral TLS certificate rotated in the background
+// and performs the TLS handshake itself before handing back a net.Conn -
+// that's why providerConfigure forces conf.TLSConfig to "false" for the
+// cloudsql path rather than letting the mysql driver negotiate TLS again on
+// top of it. cloudsql_use_iam_auth relies on WithIAMAuthN, which needs a
+// connector release that supports IAM auth for the MySQL driver specifically
+// (it landed for Postgres first) - check the pinned cloudsqlconn version if
+// IAM logins fail.
+func makeCloudSQLDialer(ctx context.Context, d *schema.ResourceData) (*cloudsqlconn.Dialer, error) {
+	var opts []cloudsqlconn.Option
+
+	if credFile, exists := d.GetOk("cloudsql_credentials_file"); exists {
+		opts = append(opts, cloudsqlconn.WithCredentialsFile(credFile.(string)))
+	}
+
+	if d.Get("cloudsql_use_iam_auth").(bool) {
+		opts = append(opts, cloudsqlconn.WithIAMAuthN())
+	}
+
+	return cloudsqlconn.NewDialer(ctx, opts...)
+}
+
+const customTLSConfigName = "custom"
+
+// maybeRegisterCustomTLSConfig builds a *tls.Config from the tls_ca_cert(_file),
+// tls_client_cert/tls_client_key, and tls_server_name provider fields and
+// registers it with the mysql driver under customTLSConfigName, returning
+// that name for the caller to use as the "tls" DSN parameter. It returns an
+// empty string if none of those fields are set, leaving the plain "tls"
+// string value untouched.
+func maybeRegisterCustomTLSConfig(d *schema.ResourceData) (string, error) {
+	caCert, err := readCACert(d)
+	if err != nil {
+		return "", err
+	}
+	clientCert, clientKey, err := readClientCertKeyPair(d)
+	if err != nil {
+		return "", err
+	}
+
+	serverName, hasServerName := d.GetOk("tls_server_name")
+
+	if caCert == nil && clientCert == nil && !hasServerName {
+		return "", nil
+	}
+
+	tlsConfig := &tls.Config{}
+
+	if hasServerName {
+		tlsConfig.ServerName = serverName.(string)
+	}
+
+	if d.Get("tls").(string) == "skip-verify" {
+		tlsConfig.InsecureSkipVerify = true
+	}
+
+	if caCert != nil {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caCert) {
+			return "", fmt.Errorf("could not parse tls_ca_cert/tls_ca_cert_file as a PEM certificate")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if clientCert != nil {
+		cert, err := tls.X509KeyPair(clientCert, clientKey)
+		if err != nil {
+			return "", fmt.Errorf("could not parse tls_client_cert/tls_client_key pair: %s", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if err := mysql.RegisterTLSConfig(customTLSConfigName, tlsConfig); err != nil {
+		return "", fmt.Errorf("could not register custom TLS config: %s", err)
+	}
+
+	return customTLSConfigName, nil
+}
+
+func readCACert(d *schema.ResourceData) ([]byte, error) {
+	if inline, ok := d.GetOk("tls_ca_cert"); ok {
+		return []byte(inline.(string)), nil
+	}
+	if path, ok := d.GetOk("tls_ca_cert_file"); ok {
+		cert, err := ioutil.ReadFile(path.(string))
+		if err != nil {
+			return nil, fmt.Errorf("could not read tls_ca_cert_file: %s", err)
+		}
+		return cert, nil
+	}
+	return nil, nil
+}
+
+func readClientCertKeyPair(d *schema.ResourceData) (cert []byte, key []byte, err error) {
+	certValue, certSet := d.GetOk("tls_client_cert")
+	keyValue, keySet := d.GetOk("tls_client_key")
+	if !certSet && !keySet {
+		return nil, nil, nil
+	}
+	if !certSet || !keySet {
+		return nil, nil, fmt.Errorf("tls_client_cert and tls_client_key must be set together")
+	}
+
+	if cert, err = resolvePEM(certValue.(string)); err != nil {
+		return nil, nil, fmt.Errorf("could not read tls_client_cert: %s", err)
+	}
+	if key, err = resolvePEM(keyValue.(string)); err != nil {
+		return nil, nil, fmt.Errorf("could not read tls_client_key: %s", err)
+	}
+	return cert, key, nil
+}
+
+// resolvePEM treats value as inline PEM content if it looks like one, and
+// otherwise as a path to a file containing it.
+func resolvePEM(value string) ([]byte, error) {
+	if strings.Contains(value, "-----BEGIN") {
+		return []byte(value), nil
+	}
+	return ioutil.ReadFile(value)
+}
+
 var identQuoteReplacer = strings.NewReplacer("`", "``")
 
 func makeDialer(d *schema.ResourceData) (proxy.Dialer, error) {
@@ -271,15 +716,47 @@ func serverVersionString(db *sql.DB) (string, error) {
 
 func connectToMySQL(conf *MySQLConfiguration) (*sql.DB, error) {
 
-	dsn := conf.Config.FormatDSN()
+	endpoints := conf.Endpoints
+	if len(endpoints) == 0 {
+		endpoints = []string{conf.Config.Addr}
+	}
+
+	// Failing over between endpoints only makes sense for a plain TCP
+	// endpoint list with more than one candidate. A single host, a Unix
+	// socket, or a connector net (cloudsql, etc.) already has the correct
+	// Addr set by providerConfigure, so it must be left untouched here.
+	failoverEligible := conf.Config.Net == "tcp" && len(endpoints) > 1
+
 	var db *sql.DB
 	var err error
+	nextHost := 0
 
 	// When provisioning a database server there can often be a lag between
 	// when Terraform thinks it's available and when it is actually available.
 	// This is particularly acute when provisioning a server and then immediately
 	// trying to provision a database on it.
 	retryError := resource.Retry(conf.ConnectRetryTimeout, func() *resource.RetryError {
+		host := conf.Config.Addr
+		if failoverEligible {
+			// Each retry advances to the next candidate host rather than
+			// re-dialing the one that just failed.
+			host = endpoints[nextHost%len(endpoints)]
+			nextHost++
+			conf.Config.Addr = host
+		}
+
+		// IAM auth tokens expire after about 15 minutes, so mint a fresh one
+		// before every connection attempt rather than reusing the token that
+		// was valid when the provider was configured.
+		if conf.TokenProvider != nil {
+			token, tokenErr := conf.TokenProvider(host)
+			if tokenErr != nil {
+				return resource.NonRetryableError(tokenErr)
+			}
+			conf.Config.Passwd = token
+		}
+
+		dsn := conf.Config.FormatDSN()
 		db, err = sql.Open("mysql", dsn)
 		if err != nil {
 			return resource.RetryableError(err)
@@ -290,6 +767,19 @@ func connectToMySQL(conf *MySQLConfiguration) (*sql.DB, error) {
 			return resource.RetryableError(err)
 		}
 
+		if conf.RequirePrimary {
+			var readOnly int
+			if err = db.QueryRow("SELECT @@read_only").Scan(&readOnly); err != nil {
+				return resource.RetryableError(err)
+			}
+			if readOnly != 0 {
+				db.Close()
+				return resource.RetryableError(fmt.Errorf("host %s is read-only, skipping", host))
+			}
+		}
+
+		log.Printf("[DEBUG] connected to MySQL host %s", host)
+		conf.ActiveEndpoint = host
 		return nil
 	})
 